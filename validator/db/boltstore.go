@@ -0,0 +1,181 @@
+package db
+
+import (
+	"bytes"
+	"os"
+	"sync"
+
+	bolt "github.com/boltdb/bolt"
+
+	storcomm "github.com/Ontology/core/store/common"
+)
+
+var boltBucket = []byte("kv")
+
+// boltStore is a BoltDB-backed storcomm.IStore, registered under the
+// "bolt" driver name. It is a drop-in alternative to leveldbstore for
+// deployments that prefer a single mmap'd file over an LSM tree.
+type boltStore struct {
+	db *bolt.DB
+
+	batchMu sync.Mutex
+	batch   map[string][]byte
+	deletes map[string]bool
+}
+
+func openBoltDriver(path string, opts Options) (storcomm.IStore, error) {
+	db, err := bolt.Open(path, os.FileMode(0644), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get(key)
+		if v == nil {
+			return errKeyNotFound
+		}
+		value = append([]byte{}, v...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (s *boltStore) Put(key, value []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+}
+
+func (s *boltStore) Delete(key []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+}
+
+func (s *boltStore) NewBatch() {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+	s.batch = make(map[string][]byte)
+	s.deletes = make(map[string]bool)
+}
+
+func (s *boltStore) BatchPut(key, value []byte) error {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+	s.batch[string(key)] = append([]byte{}, value...)
+	delete(s.deletes, string(key))
+	return nil
+}
+
+func (s *boltStore) BatchDelete(key []byte) error {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+	s.deletes[string(key)] = true
+	delete(s.batch, string(key))
+	return nil
+}
+
+func (s *boltStore) BatchCommit() error {
+	s.batchMu.Lock()
+	batch, deletes := s.batch, s.deletes
+	s.batch, s.deletes = nil, nil
+	s.batchMu.Unlock()
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for k, v := range batch {
+			if err := bucket.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+		for k := range deletes {
+			if err := bucket.Delete([]byte(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// NewIterator opens a read-only bolt transaction that stays open across
+// Next() calls, cursoring forward one key at a time. Export/Prune walk
+// the whole DATA_Transaction/ST_TransactionMeta keyspace, so this must
+// not slurp the matching range into memory up front the way an
+// in-memory driver can afford to. If the transaction can't be opened,
+// the failure is kept on the iterator and surfaced through Error() once
+// Next() reports no more keys, rather than being swallowed as "empty".
+func (s *boltStore) NewIterator(prefix []byte) storcomm.StoreIterator {
+	tx, err := s.db.Begin(false)
+	if err != nil {
+		return &boltIterator{err: err}
+	}
+	return &boltIterator{
+		tx:     tx,
+		cursor: tx.Bucket(boltBucket).Cursor(),
+		prefix: prefix,
+	}
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+type boltIterator struct {
+	tx      *bolt.Tx
+	cursor  *bolt.Cursor
+	prefix  []byte
+	started bool
+	key     []byte
+	value   []byte
+	err     error
+}
+
+func (it *boltIterator) Next() bool {
+	if it.err != nil || it.cursor == nil {
+		return false
+	}
+
+	var k, v []byte
+	if !it.started {
+		it.started = true
+		k, v = it.cursor.Seek(it.prefix)
+	} else {
+		k, v = it.cursor.Next()
+	}
+
+	if k == nil || !bytes.HasPrefix(k, it.prefix) {
+		return false
+	}
+	it.key = append([]byte{}, k...)
+	it.value = append([]byte{}, v...)
+	return true
+}
+
+func (it *boltIterator) Key() []byte   { return it.key }
+func (it *boltIterator) Value() []byte { return it.value }
+func (it *boltIterator) Error() error  { return it.err }
+
+func (it *boltIterator) Release() {
+	if it.tx != nil {
+		it.tx.Rollback()
+		it.tx = nil
+		it.cursor = nil
+	}
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"sync/atomic"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	pool "github.com/valyala/bytebufferpool"
+
+	"github.com/Ontology/common"
+)
+
+// StoreConfig sizes the read caches kept in front of the kv backend.
+// A zero value for any field disables that particular cache.
+type StoreConfig struct {
+	TransactionCacheSize     int
+	TransactionMetaCacheSize int
+	HeaderCacheSize          int
+}
+
+// DefaultStoreConfig returns the sizes NewStore uses when callers don't
+// care to tune them.
+func DefaultStoreConfig() StoreConfig {
+	return StoreConfig{
+		TransactionCacheSize:     4096,
+		TransactionMetaCacheSize: 4096,
+		HeaderCacheSize:          256,
+	}
+}
+
+// CacheStats reports hit/miss counts accumulated since the store was
+// opened, broken down by the cache that served (or missed) the read.
+type CacheStats struct {
+	TransactionHits     uint64
+	TransactionMisses   uint64
+	TransactionMetaHits   uint64
+	TransactionMetaMisses uint64
+	HeaderHits          uint64
+	HeaderMisses        uint64
+}
+
+// storeCaches bundles the LRU caches and their counters. A nil *lru.Cache
+// field means that cache was configured with size 0 and is disabled.
+type storeCaches struct {
+	txBytes *lru.Cache // common.Uint256 -> []byte
+	tx      *lru.Cache // common.Uint256 -> *types.Transaction
+	txMeta  *lru.Cache // common.Uint256 -> TransactionMeta
+	header  *lru.Cache // common.Uint256 -> *types.Header
+
+	txHits, txMisses         uint64
+	txMetaHits, txMetaMisses uint64
+	headerHits, headerMisses uint64
+}
+
+func newStoreCaches(cfg StoreConfig) (*storeCaches, error) {
+	caches := &storeCaches{}
+
+	if cfg.TransactionCacheSize > 0 {
+		txBytes, err := lru.New(cfg.TransactionCacheSize)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := lru.New(cfg.TransactionCacheSize)
+		if err != nil {
+			return nil, err
+		}
+		caches.txBytes = txBytes
+		caches.tx = tx
+	}
+
+	if cfg.TransactionMetaCacheSize > 0 {
+		txMeta, err := lru.New(cfg.TransactionMetaCacheSize)
+		if err != nil {
+			return nil, err
+		}
+		caches.txMeta = txMeta
+	}
+
+	if cfg.HeaderCacheSize > 0 {
+		header, err := lru.New(cfg.HeaderCacheSize)
+		if err != nil {
+			return nil, err
+		}
+		caches.header = header
+	}
+
+	return caches, nil
+}
+
+func (c *storeCaches) invalidateTransaction(hash common.Uint256) {
+	if c.txBytes != nil {
+		c.txBytes.Remove(hash)
+	}
+	if c.tx != nil {
+		c.tx.Remove(hash)
+	}
+}
+
+func (c *storeCaches) invalidateTransactionMeta(hash common.Uint256) {
+	if c.txMeta != nil {
+		c.txMeta.Remove(hash)
+	}
+}
+
+func (c *storeCaches) invalidateHeader(hash common.Uint256) {
+	if c.header != nil {
+		c.header.Remove(hash)
+	}
+}
+
+// GenHeaderKey builds the DATA_Header{hash} key for GetHeaderByHash,
+// following the same keyPool-backed convention as GenDataTransactionKey.
+func GenHeaderKey(hash common.Uint256) *pool.ByteBuffer {
+	key := keyPool.Get()
+	key.WriteByte(byte(DATA_Header))
+	hash.Serialize(key)
+	return key
+}
+
+// Stats returns the current cache hit/miss counters.
+func (self *Store) Stats() CacheStats {
+	if self.caches == nil {
+		return CacheStats{}
+	}
+	return CacheStats{
+		TransactionHits:       atomic.LoadUint64(&self.caches.txHits),
+		TransactionMisses:     atomic.LoadUint64(&self.caches.txMisses),
+		TransactionMetaHits:   atomic.LoadUint64(&self.caches.txMetaHits),
+		TransactionMetaMisses: atomic.LoadUint64(&self.caches.txMetaMisses),
+		HeaderHits:            atomic.LoadUint64(&self.caches.headerHits),
+		HeaderMisses:          atomic.LoadUint64(&self.caches.headerMisses),
+	}
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	storcomm "github.com/Ontology/core/store/common"
+)
+
+// errKeyNotFound is returned by the bolt/memory drivers for a missing
+// key, matching leveldbstore's not-found contract (the Get callers in
+// this package only check err != nil).
+var errKeyNotFound = errors.New("key not found")
+
+// Options carries driver-specific construction knobs. Drivers ignore
+// keys they don't recognize; the leveldb, bolt and memory drivers in
+// this package read no keys at all (cache sizes come from StoreConfig).
+type Options map[string]interface{}
+
+// Driver opens a storcomm.IStore rooted at path. Registered drivers are
+// looked up by name from NewStoreWithDriver, the same way database/sql
+// resolves a driver name to a driver.Driver.
+type Driver func(path string, opts Options) (storcomm.IStore, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Driver)
+)
+
+// RegisterDriver makes a kv backend available under name. It is meant to
+// be called from a driver package's init(), and panics on duplicate
+// registration the same way database/sql.Register does.
+func RegisterDriver(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if driver == nil {
+		panic("db: RegisterDriver driver is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("db: RegisterDriver called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// iteratorError reports the failure, if any, behind an exhausted
+// iterator. Not every storcomm.StoreIterator implementation can fail
+// after construction (the in-memory driver can't), so this only type
+// -asserts the optional Error() method rather than requiring it.
+func iteratorError(it storcomm.StoreIterator) error {
+	if e, ok := it.(interface{ Error() error }); ok {
+		return e.Error()
+	}
+	return nil
+}
+
+func openDriver(name, path string, opts Options) (storcomm.IStore, error) {
+	driversMu.RLock()
+	driver, ok := drivers[name]
+	driversMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("db: unknown driver %q (forgotten import?)", name)
+	}
+	return driver(path, opts)
+}
+
+func init() {
+	RegisterDriver("leveldb", openLevelDBDriver)
+	RegisterDriver("bolt", openBoltDriver)
+	RegisterDriver("memory", openMemDriver)
+}
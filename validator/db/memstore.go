@@ -0,0 +1,105 @@
+package db
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	storcomm "github.com/Ontology/core/store/common"
+)
+
+// memStore is an in-memory storcomm.IStore, registered under the
+// "memory" driver name. It exists so PersistBlock/init and friends can
+// be unit tested without touching disk; path is ignored.
+type memStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+func openMemDriver(path string, opts Options) (storcomm.IStore, error) {
+	return &memStore{data: make(map[string][]byte)}, nil
+}
+
+func (s *memStore) Get(key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.data[string(key)]
+	if !ok {
+		return nil, errKeyNotFound
+	}
+	return append([]byte{}, v...), nil
+}
+
+func (s *memStore) Put(key, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+func (s *memStore) Delete(key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, string(key))
+	return nil
+}
+
+func (s *memStore) NewBatch() {}
+
+func (s *memStore) BatchPut(key, value []byte) error {
+	return s.Put(key, value)
+}
+
+func (s *memStore) BatchDelete(key []byte) error {
+	return s.Delete(key)
+}
+
+func (s *memStore) BatchCommit() error {
+	return nil
+}
+
+func (s *memStore) NewIterator(prefix []byte) storcomm.StoreIterator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		if bytes.HasPrefix([]byte(k), prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	it := &memIterator{}
+	for _, k := range keys {
+		it.keys = append(it.keys, []byte(k))
+		it.values = append(it.values, s.data[k])
+	}
+	return it
+}
+
+func (s *memStore) Close() error {
+	return nil
+}
+
+type memIterator struct {
+	keys   [][]byte
+	values [][]byte
+	pos    int
+}
+
+func (it *memIterator) Next() bool {
+	if it.pos >= len(it.keys) {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+func (it *memIterator) Key() []byte   { return it.keys[it.pos-1] }
+func (it *memIterator) Value() []byte { return it.values[it.pos-1] }
+func (it *memIterator) Error() error  { return nil }
+func (it *memIterator) Release()      {}
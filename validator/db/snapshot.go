@@ -0,0 +1,344 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	pool "github.com/valyala/bytebufferpool"
+
+	"github.com/Ontology/common"
+	"github.com/Ontology/common/serialization"
+	"github.com/Ontology/core/types"
+)
+
+// snapshot key prefixes, laid out above the existing SYS_ range so they
+// never collide with prefixes assigned before this feature existed.
+const (
+	SYS_SnapshotManifest byte = 0xf1
+)
+
+// snapshotMagic/snapshotVersion identify the framed stream produced by
+// ExportSnapshot, so ImportSnapshot can reject foreign or stale dumps
+// before it starts mutating the db.
+var snapshotMagic = [4]byte{'P', 'S', 'N', 'P'}
+
+const snapshotVersion uint32 = 1
+
+// section markers inside the framed stream.
+const (
+	snapshotSectionGenesis byte = iota
+	snapshotSectionHeader
+	snapshotSectionTxMeta
+	snapshotSectionTx
+	snapshotSectionEnd
+)
+
+// Snapshot is the manifest describing a point-in-time export: enough to
+// let a peer verify it is bootstrapping to the height it asked for.
+type Snapshot struct {
+	Height          uint32
+	GenesisHash     common.Uint256
+	BestBlockHeight uint32
+	BestBlockHash   common.Uint256
+}
+
+// Snapshot builds (and persists) a manifest for height. Only the current
+// best height is supported today, since the store keeps no historical
+// state index to reconstruct an older height from.
+func (self *Store) Snapshot(height uint32) (*Snapshot, error) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	if self.bestBlockHeader == nil || self.genesisBlock == nil {
+		return nil, errors.New("fresh db")
+	}
+	if height != self.bestBlockHeader.Height {
+		return nil, fmt.Errorf("snapshot height %d not available, best height is %d", height, self.bestBlockHeader.Height)
+	}
+
+	snap := &Snapshot{
+		Height:          height,
+		GenesisHash:     self.genesisBlock.Hash(),
+		BestBlockHeight: self.bestBlockHeader.Height,
+		BestBlockHash:   self.bestBlockHeader.Hash(),
+	}
+
+	value := valuePool.Get()
+	defer valuePool.Put(value)
+	serialization.WriteUint32(value, snap.Height)
+	snap.GenesisHash.Serialize(value)
+	serialization.WriteUint32(value, snap.BestBlockHeight)
+	snap.BestBlockHash.Serialize(value)
+
+	key := GenSnapshotManifestKey(height)
+	defer keyPool.Put(key)
+	if err := self.db.Put(key.Bytes(), value.Bytes()); err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+// ExportSnapshot writes a self-describing framed stream (magic + version
+// + tagged sections for the genesis block, the best block header, tx
+// bytes and tx-meta) so a fresh node can bootstrap from a peer without
+// replaying every block. Headers other than the best one are not written.
+func (self *Store) ExportSnapshot(w io.Writer) error {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	if self.bestBlockHeader == nil || self.genesisBlock == nil {
+		return errors.New("fresh db")
+	}
+
+	if _, err := w.Write(snapshotMagic[:]); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, snapshotVersion); err != nil {
+		return err
+	}
+
+	if err := writeSnapshotSection(w, snapshotSectionGenesis, func(buf *bytes.Buffer) error {
+		return self.genesisBlock.Serialize(buf)
+	}); err != nil {
+		return err
+	}
+
+	if err := writeSnapshotSection(w, snapshotSectionHeader, func(buf *bytes.Buffer) error {
+		return self.bestBlockHeader.Serialize(buf)
+	}); err != nil {
+		return err
+	}
+
+	txPrefix := []byte{byte(DATA_Transaction)}
+	iter := self.db.NewIterator(txPrefix)
+	for iter.Next() {
+		key := append([]byte{}, iter.Key()...)
+		val := append([]byte{}, iter.Value()...)
+		err := writeSnapshotSection(w, snapshotSectionTx, func(buf *bytes.Buffer) error {
+			if err := serialization.WriteVarBytes(buf, key); err != nil {
+				return err
+			}
+			return serialization.WriteVarBytes(buf, val)
+		})
+		if err != nil {
+			iter.Release()
+			return err
+		}
+	}
+	if err := iteratorError(iter); err != nil {
+		iter.Release()
+		return err
+	}
+	iter.Release()
+
+	metaPrefix := []byte{byte(ST_TransactionMeta)}
+	iter = self.db.NewIterator(metaPrefix)
+	for iter.Next() {
+		key := append([]byte{}, iter.Key()...)
+		val := append([]byte{}, iter.Value()...)
+		err := writeSnapshotSection(w, snapshotSectionTxMeta, func(buf *bytes.Buffer) error {
+			if err := serialization.WriteVarBytes(buf, key); err != nil {
+				return err
+			}
+			return serialization.WriteVarBytes(buf, val)
+		})
+		if err != nil {
+			iter.Release()
+			return err
+		}
+	}
+	if err := iteratorError(iter); err != nil {
+		iter.Release()
+		return err
+	}
+	iter.Release()
+
+	_, err := w.Write([]byte{snapshotSectionEnd})
+	return err
+}
+
+// ImportSnapshot restores a store from a stream produced by
+// ExportSnapshot. It only runs against a fresh db (mirroring the
+// freshness check init() does): importing into a node that already has
+// a genesis/best-header would otherwise leave that chain's leftover
+// tx/header rows mixed in with the newly-imported one, since the stream
+// only replays what ExportSnapshot walked, not a full wipe.
+func (self *Store) ImportSnapshot(r io.Reader) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.bestBlockHeader != nil || self.genesisBlock != nil {
+		return errors.New("not a fresh db")
+	}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return err
+	}
+	if magic != snapshotMagic {
+		return errors.New("not a snapshot stream")
+	}
+	version, err := serialization.ReadUint32(r)
+	if err != nil {
+		return err
+	}
+	if version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", version)
+	}
+
+	self.db.NewBatch()
+	var genesis *types.Block
+	var header *types.Header
+
+	for {
+		section, err := readSnapshotSectionTag(r)
+		if err != nil {
+			return err
+		}
+		if section == snapshotSectionEnd {
+			break
+		}
+
+		payload, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return err
+		}
+
+		switch section {
+		case snapshotSectionGenesis:
+			genesis = new(types.Block)
+			if err := genesis.Deserialize(bytes.NewBuffer(payload)); err != nil {
+				return fmt.Errorf("snapshot: genesis deserialize failed: %v", err)
+			}
+			self.db.BatchPut([]byte{byte(SYS_GenesisBlock)}, payload)
+		case snapshotSectionHeader:
+			header = new(types.Header)
+			if err := header.Deserialize(bytes.NewBuffer(payload)); err != nil {
+				return fmt.Errorf("snapshot: best header deserialize failed: %v", err)
+			}
+			self.db.BatchPut([]byte{byte(SYS_BestBlockHeader)}, payload)
+		case snapshotSectionTx, snapshotSectionTxMeta:
+			buf := bytes.NewBuffer(payload)
+			key, err := serialization.ReadVarBytes(buf)
+			if err != nil {
+				return err
+			}
+			val, err := serialization.ReadVarBytes(buf)
+			if err != nil {
+				return err
+			}
+			self.db.BatchPut(key, val)
+		default:
+			return fmt.Errorf("snapshot: unknown section tag %d", section)
+		}
+	}
+
+	if err := self.db.BatchCommit(); err != nil {
+		return err
+	}
+
+	self.genesisBlock = genesis
+	self.bestBlockHeader = header
+
+	return nil
+}
+
+// Prune trims transaction data older than keepHeights blocks, retaining
+// header/best-block metadata. It takes the same mutex as PersistBlock so
+// pruning cannot race a concurrent snapshot export.
+func (self *Store) Prune(keepHeights uint32) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	if self.bestBlockHeader == nil {
+		return errors.New("fresh db")
+	}
+	if self.bestBlockHeader.Height <= keepHeights {
+		return nil
+	}
+	cutoff := self.bestBlockHeader.Height - keepHeights
+
+	txPrefix := []byte{byte(DATA_Transaction)}
+	iter := self.db.NewIterator(txPrefix)
+	var staleKeys [][]byte
+	for iter.Next() {
+		val := iter.Value()
+		if len(val) < 4 {
+			continue
+		}
+		height, err := serialization.ReadUint32(bytes.NewBuffer(val[:4]))
+		if err != nil {
+			continue
+		}
+		if height < cutoff {
+			staleKeys = append(staleKeys, append([]byte{}, iter.Key()...))
+		}
+	}
+	iterErr := iteratorError(iter)
+	iter.Release()
+	if iterErr != nil {
+		return iterErr
+	}
+
+	if len(staleKeys) == 0 {
+		return nil
+	}
+
+	self.db.NewBatch()
+	for _, key := range staleKeys {
+		self.db.BatchDelete(key)
+	}
+	if err := self.db.BatchCommit(); err != nil {
+		return err
+	}
+
+	// The rows above are gone from the backing store; drop them from the
+	// read caches too, or a pruned tx keeps being served (and
+	// ContainTransaction keeps reporting true) until it ages out of the LRU.
+	if self.caches != nil {
+		for _, key := range staleKeys {
+			if len(key) < 2 {
+				continue
+			}
+			hash, err := common.Uint256ParseFromBytes(key[1:])
+			if err != nil {
+				continue
+			}
+			self.caches.invalidateTransaction(hash)
+		}
+	}
+
+	return nil
+}
+
+// GenSnapshotManifestKey builds the SYS_SnapshotManifest{height} key,
+// following the same keyPool-backed convention as the other Gen*Key
+// helpers in this package.
+func GenSnapshotManifestKey(height uint32) *pool.ByteBuffer {
+	key := keyPool.Get()
+	key.WriteByte(byte(SYS_SnapshotManifest))
+	serialization.WriteUint32(key, height)
+	return key
+}
+
+func writeSnapshotSection(w io.Writer, tag byte, fill func(buf *bytes.Buffer) error) error {
+	buf := bytes.NewBuffer(nil)
+	if err := fill(buf); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{tag}); err != nil {
+		return err
+	}
+	return serialization.WriteVarBytes(w, buf.Bytes())
+}
+
+func readSnapshotSectionTag(r io.Reader) (byte, error) {
+	var tag [1]byte
+	if _, err := io.ReadFull(r, tag[:]); err != nil {
+		return 0, err
+	}
+	return tag[0], nil
+}
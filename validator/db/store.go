@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	pool "github.com/valyala/bytebufferpool"
 
@@ -21,20 +22,36 @@ var keyPool pool.Pool
 var valuePool pool.Pool
 
 type Store struct {
-	db storcomm.IStore
+	db     storcomm.IStore
+	caches *storeCaches
 
 	mutex           sync.RWMutex // guard the following var
 	bestBlockHeader *types.Header
 	genesisBlock    *types.Block
 }
 
+// NewStore opens the default (leveldb) backend at path, sized with
+// DefaultStoreConfig. Use NewStoreWithDriver to pick a different
+// registered backend, e.g. the "bolt" or "memory" drivers, or to tune
+// cache sizes.
 func NewStore(path string) (*Store, error) {
-	ldb, err := leveldb.NewLevelDBStore(path)
+	return NewStoreWithDriver("leveldb", path, nil, DefaultStoreConfig())
+}
+
+// NewStoreWithDriver opens a Store backed by the named, previously
+// registered driver. opts is passed through verbatim and may be nil.
+func NewStoreWithDriver(driver, path string, opts Options, cfg StoreConfig) (*Store, error) {
+	kv, err := openDriver(driver, path, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	st := &Store{db: ldb}
+	caches, err := newStoreCaches(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Store{db: kv, caches: caches}
 	err = st.init()
 	if err != nil {
 		return nil, err
@@ -43,6 +60,10 @@ func NewStore(path string) (*Store, error) {
 	return st, nil
 }
 
+func openLevelDBDriver(path string, opts Options) (storcomm.IStore, error) {
+	return leveldb.NewLevelDBStore(path)
+}
+
 func (self *Store) init() error {
 	prefix := []byte{byte(SYS_Version)}
 	version, err := self.db.Get(prefix)
@@ -123,14 +144,36 @@ func (self *Store) ContainTransaction(hash common.Uint256) bool {
 }
 
 func (self *Store) GetTransactionBytes(hash common.Uint256) ([]byte, error) {
+	if self.caches != nil && self.caches.txBytes != nil {
+		if cached, ok := self.caches.txBytes.Get(hash); ok {
+			atomic.AddUint64(&self.caches.txHits, 1)
+			return cached.([]byte), nil
+		}
+	}
+
 	key := GenDataTransactionKey(hash)
 	defer keyPool.Put(key)
 	txn, err := self.db.Get(key.Bytes())
+	if err != nil {
+		return nil, err
+	}
 
-	return txn, err
+	if self.caches != nil && self.caches.txBytes != nil {
+		atomic.AddUint64(&self.caches.txMisses, 1)
+		self.caches.txBytes.Add(hash, txn)
+	}
+
+	return txn, nil
 }
 
 func (self *Store) GetTransaction(hash common.Uint256) (*tx.Transaction, error) {
+	if self.caches != nil && self.caches.tx != nil {
+		if cached, ok := self.caches.tx.Get(hash); ok {
+			atomic.AddUint64(&self.caches.txHits, 1)
+			return cached.(*tx.Transaction), nil
+		}
+	}
+
 	buf, err := self.GetTransactionBytes(hash)
 	if err != nil {
 		return nil, err
@@ -140,11 +183,23 @@ func (self *Store) GetTransaction(hash common.Uint256) (*tx.Transaction, error)
 	if err != nil {
 		return nil, err
 	}
+
+	if self.caches != nil && self.caches.tx != nil {
+		self.caches.tx.Add(hash, txn)
+	}
+
 	return txn, nil
 }
 
 //implement  TransactionMetaProvider interface
 func (self *Store) GetTransactionMeta(hash common.Uint256) (TransactionMeta, error) {
+	if self.caches != nil && self.caches.txMeta != nil {
+		if cached, ok := self.caches.txMeta.Get(hash); ok {
+			atomic.AddUint64(&self.caches.txMetaHits, 1)
+			return cached.(TransactionMeta), nil
+		}
+	}
+
 	key := GenTxMetaKey(hash)
 	defer keyPool.Put(key)
 	txbuf, err := self.db.Get(key.Bytes())
@@ -158,9 +213,45 @@ func (self *Store) GetTransactionMeta(hash common.Uint256) (TransactionMeta, err
 		return TransactionMeta{}, err
 	}
 
+	if self.caches != nil && self.caches.txMeta != nil {
+		atomic.AddUint64(&self.caches.txMetaMisses, 1)
+		self.caches.txMeta.Add(hash, txMeta)
+	}
+
 	return txMeta, nil
 }
 
+// GetHeaderByHash looks up an arbitrary header by hash (unlike
+// GetBestHeader, which only tracks the chain tip), going through the
+// header cache first.
+func (self *Store) GetHeaderByHash(hash common.Uint256) (*types.Header, error) {
+	if self.caches != nil && self.caches.header != nil {
+		if cached, ok := self.caches.header.Get(hash); ok {
+			atomic.AddUint64(&self.caches.headerHits, 1)
+			return cached.(*types.Header), nil
+		}
+	}
+
+	key := GenHeaderKey(hash)
+	defer keyPool.Put(key)
+	buf, err := self.db.Get(key.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	header := new(types.Header)
+	if err := header.Deserialize(bytes.NewBuffer(buf)); err != nil {
+		return nil, err
+	}
+
+	if self.caches != nil && self.caches.header != nil {
+		atomic.AddUint64(&self.caches.headerMisses, 1)
+		self.caches.header.Add(hash, header)
+	}
+
+	return header, nil
+}
+
 func (self *Store) Close() error {
 	err := self.db.Close()
 	self.db = nil
@@ -179,18 +270,22 @@ func (self *Store) saveTransaction(tx *tx.Transaction, height uint32) error {
 
 	// put value
 	err := self.db.BatchPut(key.Bytes(), value.Bytes())
+	if err == nil && self.caches != nil {
+		self.caches.invalidateTransaction(tx.Hash())
+	}
 	return err
 }
 
 func (self *Store) PersistBlock(block *types.Block) error {
 	height := block.Header.Height
-	if !((self.bestBlockHeader == nil && height == 0) || height == self.bestBlockHeader.Height+1) {
-		return errors.New("can't persist discontinuous block")
-	}
 
 	self.mutex.Lock()
 	defer self.mutex.Unlock()
 
+	if !((self.bestBlockHeader == nil && height == 0) || height == self.bestBlockHeader.Height+1) {
+		return errors.New("can't persist discontinuous block")
+	}
+
 	self.db.NewBatch()
 	for _, txn := range block.Transactions {
 		err := self.saveTransaction(txn, height)
@@ -220,6 +315,12 @@ func (self *Store) PersistBlock(block *types.Block) error {
 	header.Serialize(value)
 	self.db.BatchPut(key.Bytes(), value.Bytes())
 
+	// also index the header by its own hash so GetHeaderByHash can find
+	// it later, not just the chain tip tracked under SYS_BestBlockHeader.
+	headerKey := GenHeaderKey(header.Hash())
+	defer keyPool.Put(headerKey)
+	self.db.BatchPut(headerKey.Bytes(), value.Bytes())
+
 	err := self.db.BatchCommit()
 
 	if err != nil {
@@ -231,5 +332,9 @@ func (self *Store) PersistBlock(block *types.Block) error {
 	}
 	self.bestBlockHeader = block.Header
 
+	if self.caches != nil {
+		self.caches.invalidateHeader(header.Hash())
+	}
+
 	return err
 }
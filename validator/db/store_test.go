@@ -0,0 +1,112 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/Ontology/common"
+	"github.com/Ontology/common/serialization"
+	"github.com/Ontology/core/types"
+)
+
+// types.Transaction carries fields this checkout's core/types package
+// doesn't expose (it lives outside this repo), so these tests build
+// rows directly with the same key/value shape saveTransaction uses
+// instead of constructing a real *types.Transaction.
+func putFakeTransaction(t *testing.T, st *Store, hash common.Uint256, height uint32) {
+	t.Helper()
+
+	key := GenDataTransactionKey(hash)
+	defer keyPool.Put(key)
+	value := valuePool.Get()
+	defer valuePool.Put(value)
+
+	serialization.WriteUint32(value, height)
+	value.Write([]byte("fake-tx-body"))
+
+	if err := st.db.Put(key.Bytes(), value.Bytes()); err != nil {
+		t.Fatalf("put fake transaction: %v", err)
+	}
+}
+
+func TestMemoryDriverFreshStore(t *testing.T) {
+	st, err := NewStoreWithDriver("memory", "", nil, DefaultStoreConfig())
+	if err != nil {
+		t.Fatalf("NewStoreWithDriver(memory): %v", err)
+	}
+	defer st.Close()
+
+	if _, err := st.GetBestBlock(); err == nil {
+		t.Fatal("expected fresh db to report no best block")
+	}
+}
+
+func TestPruneInvalidatesCache(t *testing.T) {
+	st, err := NewStoreWithDriver("memory", "", nil, DefaultStoreConfig())
+	if err != nil {
+		t.Fatalf("NewStoreWithDriver(memory): %v", err)
+	}
+	defer st.Close()
+
+	st.bestBlockHeader = &types.Header{Height: 100}
+
+	var hash common.Uint256
+	hash[0] = 0x42
+	putFakeTransaction(t, st, hash, 10) // well below the keepHeights cutoff
+
+	st.caches.tx.Add(hash, "cached-transaction")
+	st.caches.txBytes.Add(hash, []byte("cached-bytes"))
+
+	if err := st.Prune(5); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	if _, ok := st.caches.tx.Get(hash); ok {
+		t.Fatal("Prune left a stale entry in the transaction cache")
+	}
+	if _, ok := st.caches.txBytes.Get(hash); ok {
+		t.Fatal("Prune left a stale entry in the transaction bytes cache")
+	}
+
+	key := GenDataTransactionKey(hash)
+	defer keyPool.Put(key)
+	if _, err := st.db.Get(key.Bytes()); err == nil {
+		t.Fatal("Prune did not remove the row from the backing store")
+	}
+}
+
+func TestPruneKeepsRecentRows(t *testing.T) {
+	st, err := NewStoreWithDriver("memory", "", nil, DefaultStoreConfig())
+	if err != nil {
+		t.Fatalf("NewStoreWithDriver(memory): %v", err)
+	}
+	defer st.Close()
+
+	st.bestBlockHeader = &types.Header{Height: 100}
+
+	var hash common.Uint256
+	hash[0] = 0x43
+	putFakeTransaction(t, st, hash, 99) // within the keepHeights window
+
+	if err := st.Prune(5); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	key := GenDataTransactionKey(hash)
+	defer keyPool.Put(key)
+	if _, err := st.db.Get(key.Bytes()); err != nil {
+		t.Fatalf("Prune removed a row newer than the cutoff: %v", err)
+	}
+}
+
+func TestGetHeaderByHashMiss(t *testing.T) {
+	st, err := NewStoreWithDriver("memory", "", nil, DefaultStoreConfig())
+	if err != nil {
+		t.Fatalf("NewStoreWithDriver(memory): %v", err)
+	}
+	defer st.Close()
+
+	var hash common.Uint256
+	if _, err := st.GetHeaderByHash(hash); err == nil {
+		t.Fatal("expected a miss for a header that was never persisted")
+	}
+}